@@ -0,0 +1,26 @@
+package can
+
+// Frame represent a can frame
+type Frame struct {
+	// ArbitrationID is the frame identifier
+	ArbitrationID uint32
+
+	// DLC represent the size of the data field
+	DLC uint8
+
+	// Data is the data to transmit in the frame
+	Data [8]byte
+
+	// Extended marks the frame as using a 29-bit arbitration ID instead of
+	// the standard 11-bit one
+	Extended bool
+
+	// RTR marks the frame as a remote transmission request, ie. it carries
+	// no data and only solicits a reply from the node owning ArbitrationID
+	RTR bool
+}
+
+// GetData read frame.DLC data from frame.Data
+func (frame *Frame) GetData() []byte {
+	return frame.Data[0:frame.DLC]
+}
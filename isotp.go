@@ -0,0 +1,114 @@
+package can
+
+import (
+	"context"
+	"errors"
+)
+
+// isoTPFrameType is the high nibble of an ISO 15765-2 PCI byte
+type isoTPFrameType byte
+
+// ISO 15765-2 frame types
+const (
+	isoTPSingleFrame      isoTPFrameType = 0x0
+	isoTPFirstFrame       isoTPFrameType = 0x1
+	isoTPConsecutiveFrame isoTPFrameType = 0x2
+	isoTPFlowControlFrame isoTPFrameType = 0x3
+)
+
+// errISOTPClosed is returned when the response channel closes before a full
+// payload was reassembled
+var errISOTPClosed = errors.New("can: isotp response channel closed")
+
+// errISOTPMalformed is returned when a frame's PCI byte doesn't carry enough
+// length information for its frame type
+var errISOTPMalformed = errors.New("can: malformed isotp frame")
+
+// errISOTPOutOfSequence is returned when a consecutive frame's sequence
+// number doesn't follow the previous one
+var errISOTPOutOfSequence = errors.New("can: isotp consecutive frame out of sequence")
+
+// ISOTPRequest writes reqFrm on mux and reassembles a single ISO-TP payload
+// (ISO 15765-2 single/first/consecutive frames) out of the responses
+// matching matcher, sending flow-control frames back on reqFrm's
+// ArbitrationID as needed. It lets callers (eg. OBD-II, UDS) send one
+// request and receive one reassembled payload instead of handling ISO-TP
+// framing themselves
+func ISOTPRequest(ctx context.Context, mux *Multiplexer, reqFrm *Frame, matcher Matcher) ([]byte, error) {
+	ch, cancel, err := mux.Request(ctx, reqFrm, matcher)
+	if err != nil {
+		return nil, err
+	}
+	defer cancel()
+
+	var payload []byte
+	expectedLen := -1
+	seq := byte(1)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+
+		case frm, ok := <-ch:
+			if !ok {
+				return nil, errISOTPClosed
+			}
+
+			data := frm.GetData()
+			if len(data) == 0 {
+				continue
+			}
+
+			switch isoTPFrameType(data[0] >> 4) {
+			case isoTPSingleFrame:
+				length := int(data[0] & 0x0F)
+				if length > len(data)-1 {
+					return nil, errISOTPMalformed
+				}
+
+				return append([]byte(nil), data[1:1+length]...), nil
+
+			case isoTPFirstFrame:
+				if len(data) < 2 {
+					return nil, errISOTPMalformed
+				}
+
+				expectedLen = int(data[0]&0x0F)<<8 | int(data[1])
+				payload = append([]byte(nil), data[2:]...)
+				seq = 1
+
+				// Flow control : continue to send, no block size limit,
+				// no separation time
+				fc := &Frame{ArbitrationID: reqFrm.ArbitrationID, Extended: reqFrm.Extended, DLC: 3}
+				fc.Data[0] = byte(isoTPFlowControlFrame) << 4
+
+				if err := mux.transport.Write(fc); err != nil {
+					return nil, err
+				}
+
+			case isoTPConsecutiveFrame:
+				if expectedLen < 0 {
+					// Consecutive frame with no preceding first frame; ignore
+					continue
+				}
+
+				if data[0]&0x0F != seq&0x0F {
+					return nil, errISOTPOutOfSequence
+				}
+
+				payload = append(payload, data[1:]...)
+				seq++
+
+				if len(payload) >= expectedLen {
+					return payload[:expectedLen], nil
+				}
+
+			case isoTPFlowControlFrame:
+				// We only send flow control frames, from isoTPFirstFrame
+				// above; ignore one seen on the response side
+				continue
+			}
+		}
+	}
+}
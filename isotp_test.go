@@ -0,0 +1,91 @@
+package can
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestISOTPRequestSingleFrame(t *testing.T) {
+	tr := newFakeMuxTransport()
+	tr.replies = func(frm *Frame) []*Frame {
+		return []*Frame{{
+			ArbitrationID: 0x7E8,
+			DLC:           4,
+			Data:          [8]byte{0x03, 0x41, 0x00, 0xFF},
+		}}
+	}
+
+	mux := NewMultiplexer(tr)
+
+	reqFrm := &Frame{ArbitrationID: 0x7E0, DLC: 2, Data: [8]byte{0x01, 0x00}}
+	matcher := func(frm *Frame) bool { return frm.ArbitrationID == 0x7E8 }
+
+	payload, err := ISOTPRequest(context.Background(), mux, reqFrm, matcher)
+	if err != nil {
+		t.Fatalf("ISOTPRequest returned error: %v", err)
+	}
+
+	want := []byte{0x41, 0x00, 0xFF}
+	if string(payload) != string(want) {
+		t.Fatalf("payload = % X, want % X", payload, want)
+	}
+}
+
+func TestISOTPRequestMultiFrame(t *testing.T) {
+	tr := newFakeMuxTransport()
+
+	fullPayload := []byte{0x62, 0xF1, 0x90, 0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07}
+
+	tr.replies = func(frm *Frame) []*Frame {
+		if frm.ArbitrationID != 0x7E0 || frm.Data[0]>>4 == byte(isoTPFlowControlFrame) {
+			// Ignore our own flow control frames, only reply to the request
+			return nil
+		}
+
+		// This is the diagnostic request itself : reply with a multi-frame response
+		firstFrame := &Frame{
+			ArbitrationID: 0x7E8,
+			DLC:           8,
+			Data:          [8]byte{0x10, byte(len(fullPayload)), fullPayload[0], fullPayload[1], fullPayload[2], fullPayload[3], fullPayload[4], fullPayload[5]},
+		}
+
+		consecutiveFrame := &Frame{
+			ArbitrationID: 0x7E8,
+			DLC:           5,
+			Data:          [8]byte{0x21, fullPayload[6], fullPayload[7], fullPayload[8], fullPayload[9]},
+		}
+
+		return []*Frame{firstFrame, consecutiveFrame}
+	}
+
+	mux := NewMultiplexer(tr)
+
+	reqFrm := &Frame{ArbitrationID: 0x7E0, DLC: 2, Data: [8]byte{0x22, 0xF1}}
+	matcher := func(frm *Frame) bool { return frm.ArbitrationID == 0x7E8 }
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	payload, err := ISOTPRequest(ctx, mux, reqFrm, matcher)
+	if err != nil {
+		t.Fatalf("ISOTPRequest returned error: %v", err)
+	}
+
+	if string(payload) != string(fullPayload) {
+		t.Fatalf("payload = % X, want % X", payload, fullPayload)
+	}
+
+	// The first frame's flow control reply must have been written back on
+	// the request's arbitration ID
+	var gotFC bool
+	for _, frm := range tr.written {
+		if frm.ArbitrationID == reqFrm.ArbitrationID && frm.Data[0]>>4 == byte(isoTPFlowControlFrame) {
+			gotFC = true
+		}
+	}
+
+	if !gotFC {
+		t.Fatal("no flow control frame was written")
+	}
+}
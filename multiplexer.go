@@ -0,0 +1,125 @@
+package can
+
+import (
+	"context"
+	"sync"
+)
+
+// Matcher reports whether frm belongs to the subscriber that owns it
+type Matcher func(frm *Frame) bool
+
+// muxSub is a single subscriber : frames matching matcher are pushed onto ch
+type muxSub struct {
+	matcher Matcher
+	ch      chan *Frame
+}
+
+// Multiplexer owns the single consumer of a Transport's ReadChan and fans
+// incoming frames out to per-transaction channels and passive subscribers,
+// keyed by an internal subscription id. This lets many callers correlate
+// request/response transactions (eg. OBD-II, UDS, J1939 request PGNs)
+// without each building its own fan-out over ReadChan
+type Multiplexer struct {
+	transport Transport
+
+	mutex  sync.Mutex
+	subs   map[uint64]*muxSub
+	nextID uint64
+}
+
+// NewMultiplexer creates a Multiplexer wrapping transport and starts its
+// dispatch loop. transport must already be open
+func NewMultiplexer(transport Transport) *Multiplexer {
+	mux := &Multiplexer{
+		transport: transport,
+		subs:      make(map[uint64]*muxSub),
+	}
+
+	go mux.dispatch()
+
+	return mux
+}
+
+// dispatch is the single consumer of transport.ReadChan. It fans each frame
+// out to every subscriber whose matcher accepts it
+func (mux *Multiplexer) dispatch() {
+	for frm := range mux.transport.ReadChan() {
+		mux.mutex.Lock()
+
+		for _, sub := range mux.subs {
+			if !sub.matcher(frm) {
+				continue
+			}
+
+			// Never block the dispatch loop on a slow subscriber
+			select {
+			case sub.ch <- frm:
+			default:
+			}
+		}
+
+		mux.mutex.Unlock()
+	}
+
+	mux.mutex.Lock()
+	for id, sub := range mux.subs {
+		close(sub.ch)
+		delete(mux.subs, id)
+	}
+	mux.mutex.Unlock()
+}
+
+// subscribe registers matcher and returns its subscription id and channel
+func (mux *Multiplexer) subscribe(matcher Matcher) (uint64, chan *Frame) {
+	mux.mutex.Lock()
+	defer mux.mutex.Unlock()
+
+	mux.nextID++
+	id := mux.nextID
+
+	ch := make(chan *Frame, 16)
+	mux.subs[id] = &muxSub{matcher: matcher, ch: ch}
+
+	return id, ch
+}
+
+// unsubscribe removes and closes the subscription for id, if still present
+func (mux *Multiplexer) unsubscribe(id uint64) {
+	mux.mutex.Lock()
+	defer mux.mutex.Unlock()
+
+	if sub, ok := mux.subs[id]; ok {
+		close(sub.ch)
+		delete(mux.subs, id)
+	}
+}
+
+// Subscribe registers a passive listener for frames matching matcher. The
+// returned cancel func must be called once the caller is done listening, to
+// release the subscription
+func (mux *Multiplexer) Subscribe(matcher Matcher) (<-chan *Frame, func()) {
+	id, ch := mux.subscribe(matcher)
+	return ch, func() { mux.unsubscribe(id) }
+}
+
+// Request writes frm on the transport and returns a channel of subsequent
+// frames matching matcher, along with a cancel func that must be called once
+// the caller is done reading from it (eg. via defer), to release the
+// subscription. The channel itself is not closed on ctx cancellation; the
+// caller is expected to stop reading from it once ctx is done
+func (mux *Multiplexer) Request(ctx context.Context, frm *Frame, matcher Matcher) (<-chan *Frame, func(), error) {
+	id, ch := mux.subscribe(matcher)
+	cancel := func() { mux.unsubscribe(id) }
+
+	if err := mux.transport.Write(frm); err != nil {
+		cancel()
+		return nil, nil, err
+	}
+
+	go func() {
+		<-ctx.Done()
+		cancel()
+	}()
+
+	return ch, cancel, nil
+}
@@ -0,0 +1,105 @@
+package can
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// fakeMuxTransport is an in-memory Transport for exercising Multiplexer
+// without a real connection. Write appends to written and, if set, replies
+// feeds frm back via readChan
+type fakeMuxTransport struct {
+	readChan chan *Frame
+	written  []*Frame
+	replies  func(frm *Frame) []*Frame
+}
+
+func newFakeMuxTransport() *fakeMuxTransport {
+	return &fakeMuxTransport{readChan: make(chan *Frame, 16)}
+}
+
+func (t *fakeMuxTransport) Open(ctx context.Context) error     { return nil }
+func (t *fakeMuxTransport) Shutdown(ctx context.Context) error { close(t.readChan); return nil }
+func (t *fakeMuxTransport) ReadChan() chan *Frame              { return t.readChan }
+
+func (t *fakeMuxTransport) Write(frm *Frame) error {
+	t.written = append(t.written, frm)
+
+	if t.replies == nil {
+		return nil
+	}
+
+	for _, reply := range t.replies(frm) {
+		t.readChan <- reply
+	}
+
+	return nil
+}
+
+func TestMultiplexerRequest(t *testing.T) {
+	tr := newFakeMuxTransport()
+	tr.replies = func(frm *Frame) []*Frame {
+		return []*Frame{{ArbitrationID: frm.ArbitrationID + 8, DLC: 1, Data: [8]byte{0x42}}}
+	}
+
+	mux := NewMultiplexer(tr)
+
+	reqFrm := &Frame{ArbitrationID: 0x7E0, DLC: 1, Data: [8]byte{0x01}}
+	matcher := func(frm *Frame) bool { return frm.ArbitrationID == 0x7E8 }
+
+	ch, cancel, err := mux.Request(context.Background(), reqFrm, matcher)
+	if err != nil {
+		t.Fatalf("Request returned error: %v", err)
+	}
+	defer cancel()
+
+	select {
+	case frm := <-ch:
+		if frm.ArbitrationID != 0x7E8 {
+			t.Fatalf("got frame with ArbitrationID %#x, want 0x7E8", frm.ArbitrationID)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for response frame")
+	}
+}
+
+func TestMultiplexerSubscribe(t *testing.T) {
+	tr := newFakeMuxTransport()
+	mux := NewMultiplexer(tr)
+
+	ch, cancel := mux.Subscribe(func(frm *Frame) bool { return frm.ArbitrationID == 0x123 })
+	defer cancel()
+
+	tr.readChan <- &Frame{ArbitrationID: 0x456}
+	tr.readChan <- &Frame{ArbitrationID: 0x123}
+
+	select {
+	case frm := <-ch:
+		if frm.ArbitrationID != 0x123 {
+			t.Fatalf("got frame with ArbitrationID %#x, want 0x123", frm.ArbitrationID)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for subscribed frame")
+	}
+}
+
+func TestMultiplexerRequestTimeout(t *testing.T) {
+	tr := newFakeMuxTransport()
+	mux := NewMultiplexer(tr)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	ch, reqCancel, err := mux.Request(ctx, &Frame{ArbitrationID: 0x7E0}, func(frm *Frame) bool { return true })
+	if err != nil {
+		t.Fatalf("Request returned error: %v", err)
+	}
+	defer reqCancel()
+
+	select {
+	case <-ch:
+		t.Fatal("expected no frame before timeout")
+	case <-ctx.Done():
+	}
+}
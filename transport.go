@@ -0,0 +1,19 @@
+package can
+
+import "context"
+
+// Transport interface can be socketcan, an serial adapter, custom implementation, etc
+type Transport interface {
+	// Open a connection. Open must not block past ctx's deadline/cancellation
+	Open(ctx context.Context) error
+
+	// Shutdown gracefully stops the connection, waiting for in-flight work
+	// to settle until ctx is done
+	Shutdown(ctx context.Context) error
+
+	// Write a frame to connection
+	Write(*Frame) error
+
+	// ReadChan return the channel for reading frames
+	ReadChan() chan *Frame
+}
@@ -0,0 +1,164 @@
+package transports
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+
+	"github.com/jaster-prj/go-can"
+)
+
+const (
+	// canalystSOF is the byte marking the start of a Canalyst binary frame
+	canalystSOF = 0xAA
+
+	// canalystEOF is the byte marking the end of a Canalyst binary frame
+	canalystEOF = 0x55
+
+	// canalystTypeBase is the fixed high bits of the type byte
+	canalystTypeBase = 0xC0
+
+	// canalystTypeExtended is the type byte bit selecting a 29-bit extended
+	// arbitration ID instead of the standard 11-bit one
+	canalystTypeExtended = 0x20
+
+	// canalystTypeRTR is the type byte bit marking a remote transmission
+	// request frame
+	canalystTypeRTR = 0x10
+
+	// canalystTypeDLCMask isolates the DLC nibble of the type byte
+	canalystTypeDLCMask = 0x0F
+
+	// canalystStdIDLen is the arbitration ID size, in bytes, of a standard frame
+	canalystStdIDLen = 2
+
+	// canalystExtIDLen is the arbitration ID size, in bytes, of an extended frame
+	canalystExtIDLen = 4
+)
+
+// canalystMinFrameLen is the minimum size of a standard Canalyst binary
+// frame (SOF + type byte + arbitration ID + EOF), before any data bytes
+const canalystMinFrameLen = 1 + 1 + canalystStdIDLen + 1
+
+// errBadCanalystTerminator is returned by CanalystBinaryFramer.Decode when a
+// frame does not end with the expected EOF byte. The USB cananalyzer has a
+// bug and sometimes returns wrong data fields
+var errBadCanalystTerminator = errors.New("transports: canalyst frame missing EOF byte")
+
+// errBadCanalystDLC is returned by CanalystBinaryFramer.Decode when the DLC
+// nibble exceeds 8, the maximum a Frame.Data array can hold
+var errBadCanalystDLC = errors.New("transports: canalyst frame DLC exceeds 8")
+
+// CanalystBinaryFramer implements Framer for the binary protocol spoken by
+// Canalyst/CH340 based USB-CAN adapters: SOF (0xAA) + type byte + arbitration
+// ID + data + EOF (0x55). The type byte packs the DLC in its low nibble and,
+// in its high nibble, a bit selecting an extended (29-bit) over a standard
+// (11-bit) arbitration ID and a bit marking a remote transmission request.
+// Standard IDs are written as 16-bit little-endian, extended IDs as 32-bit
+// little-endian
+type CanalystBinaryFramer struct{}
+
+// Encode a frame into the Canalyst binary wire format
+func (f *CanalystBinaryFramer) Encode(frm *can.Frame) ([]byte, error) {
+	idLen := canalystStdIDLen
+	if frm.Extended {
+		idLen = canalystExtIDLen
+	}
+
+	frmFullLen := 2 + idLen + int(frm.DLC) + 1
+	data := make([]byte, frmFullLen)
+
+	// 0xAA : adapter start of frame
+	data[0] = canalystSOF
+
+	// Type byte : DLC nibble plus extended/RTR flags
+	data[1] = canalystTypeBase | (frm.DLC & canalystTypeDLCMask)
+	if frm.Extended {
+		data[1] |= canalystTypeExtended
+	}
+	if frm.RTR {
+		data[1] |= canalystTypeRTR
+	}
+
+	// Write arbitration id
+	if frm.Extended {
+		binary.LittleEndian.PutUint32(data[2:], frm.ArbitrationID)
+	} else {
+		binary.LittleEndian.PutUint16(data[2:], uint16(frm.ArbitrationID))
+	}
+
+	// Append data
+	dataOffset := 2 + idLen
+	for i := 0; i < int(frm.DLC); i++ {
+		data[dataOffset+i] = frm.Data[i]
+	}
+
+	// Adapater end of frame
+	data[frmFullLen-1] = canalystEOF
+
+	return data, nil
+}
+
+// Decode a single frame from the front of buf
+func (f *CanalystBinaryFramer) Decode(buf []byte) (*can.Frame, int, error) {
+	// Find adapter start of frame, discarding any leading garbage
+	sof := bytes.IndexByte(buf, canalystSOF)
+	if sof == -1 {
+		return nil, len(buf), nil
+	}
+	if sof > 0 {
+		return nil, sof, nil
+	}
+
+	// Check if buf can contain at least a standard frame's header, else wait for more data
+	if len(buf) < canalystMinFrameLen {
+		return nil, 0, nil
+	}
+
+	// Create new frame
+	frm := &can.Frame{}
+
+	typeByte := buf[1]
+	frm.Extended = typeByte&canalystTypeExtended != 0
+	frm.RTR = typeByte&canalystTypeRTR != 0
+	frm.DLC = typeByte & canalystTypeDLCMask
+	if frm.DLC > 8 {
+		// Ignore frame by just dropping the SOF byte
+		// The frame will be resynchronized at next call
+		return nil, 1, errBadCanalystDLC
+	}
+
+	idLen := canalystStdIDLen
+	if frm.Extended {
+		idLen = canalystExtIDLen
+	}
+
+	frameLen := 2 + idLen + int(frm.DLC) + 1
+
+	// Check buf can contain the full frame, else wait for more data
+	if len(buf) < frameLen {
+		return nil, 0, nil
+	}
+
+	// Validate frame ends with 0x55
+	if buf[frameLen-1] != canalystEOF {
+		// Ignore frame by just dropping the SOF byte
+		// The frame will be resynchronized at next call
+		return nil, 1, errBadCanalystTerminator
+	}
+
+	// Arbitration ID
+	if frm.Extended {
+		frm.ArbitrationID = binary.LittleEndian.Uint32(buf[2:])
+	} else {
+		frm.ArbitrationID = uint32(binary.LittleEndian.Uint16(buf[2:]))
+	}
+
+	// Data
+	dataOffset := 2 + idLen
+	for i := 0; i < int(frm.DLC); i++ {
+		frm.Data[i] = buf[dataOffset+i]
+	}
+
+	return frm, frameLen, nil
+}
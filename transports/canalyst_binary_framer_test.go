@@ -0,0 +1,145 @@
+package transports
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/jaster-prj/go-can"
+)
+
+func TestCanalystBinaryFramerEncodeDecode(t *testing.T) {
+	tests := []struct {
+		name  string
+		frm   *can.Frame
+		bytes []byte
+	}{
+		{
+			name: "standard data frame",
+			frm: &can.Frame{
+				ArbitrationID: 0x123,
+				DLC:           3,
+				Data:          [8]byte{0x01, 0x02, 0x03},
+			},
+			bytes: []byte{0xAA, 0xC3, 0x23, 0x01, 0x01, 0x02, 0x03, 0x55},
+		},
+		{
+			name: "standard RTR frame",
+			frm: &can.Frame{
+				ArbitrationID: 0x123,
+				DLC:           0,
+				RTR:           true,
+			},
+			bytes: []byte{0xAA, 0xD0, 0x23, 0x01, 0x55},
+		},
+		{
+			name: "extended data frame",
+			frm: &can.Frame{
+				ArbitrationID: 0x1ABCDE,
+				DLC:           2,
+				Data:          [8]byte{0xAA, 0xBB},
+				Extended:      true,
+			},
+			bytes: []byte{0xAA, 0xE2, 0xDE, 0xBC, 0x1A, 0x00, 0xAA, 0xBB, 0x55},
+		},
+		{
+			name: "extended RTR frame",
+			frm: &can.Frame{
+				ArbitrationID: 0x1ABCDE,
+				DLC:           0,
+				Extended:      true,
+				RTR:           true,
+			},
+			bytes: []byte{0xAA, 0xF0, 0xDE, 0xBC, 0x1A, 0x00, 0x55},
+		},
+	}
+
+	framer := &CanalystBinaryFramer{}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			encoded, err := framer.Encode(tt.frm)
+			if err != nil {
+				t.Fatalf("Encode returned error: %v", err)
+			}
+
+			if !bytes.Equal(encoded, tt.bytes) {
+				t.Fatalf("Encode = % X, want % X", encoded, tt.bytes)
+			}
+
+			decoded, consumed, err := framer.Decode(tt.bytes)
+			if err != nil {
+				t.Fatalf("Decode returned error: %v", err)
+			}
+
+			if consumed != len(tt.bytes) {
+				t.Fatalf("Decode consumed = %d, want %d", consumed, len(tt.bytes))
+			}
+
+			if *decoded != *tt.frm {
+				t.Fatalf("Decode = %+v, want %+v", decoded, tt.frm)
+			}
+		})
+	}
+}
+
+func TestCanalystBinaryFramerDecodeIncomplete(t *testing.T) {
+	framer := &CanalystBinaryFramer{}
+
+	// Standard data frame, missing its final data byte and EOF
+	buf := []byte{0xAA, 0xC3, 0x23, 0x01, 0x01, 0x02}
+
+	frm, consumed, err := framer.Decode(buf)
+	if err != nil {
+		t.Fatalf("Decode returned error: %v", err)
+	}
+
+	if frm != nil || consumed != 0 {
+		t.Fatalf("Decode = (%+v, %d), want (nil, 0)", frm, consumed)
+	}
+}
+
+func TestCanalystBinaryFramerDecodeBadTerminator(t *testing.T) {
+	framer := &CanalystBinaryFramer{}
+
+	buf := []byte{0xAA, 0xC0, 0x23, 0x01, 0x00}
+
+	frm, consumed, err := framer.Decode(buf)
+	if err != errBadCanalystTerminator {
+		t.Fatalf("Decode err = %v, want errBadCanalystTerminator", err)
+	}
+
+	if frm != nil || consumed != 1 {
+		t.Fatalf("Decode = (%+v, %d), want (nil, 1)", frm, consumed)
+	}
+}
+
+func TestCanalystBinaryFramerDecodeOverlongDLC(t *testing.T) {
+	framer := &CanalystBinaryFramer{}
+
+	// Type byte DLC nibble of 9 exceeds the 8-byte Data array
+	buf := []byte{0xAA, 0xC9, 0x23, 0x01, 0x55}
+
+	frm, consumed, err := framer.Decode(buf)
+	if err != errBadCanalystDLC {
+		t.Fatalf("Decode err = %v, want errBadCanalystDLC", err)
+	}
+
+	if frm != nil || consumed != 1 {
+		t.Fatalf("Decode = (%+v, %d), want (nil, 1)", frm, consumed)
+	}
+}
+
+func TestCanalystBinaryFramerDecodeSkipsGarbage(t *testing.T) {
+	framer := &CanalystBinaryFramer{}
+
+	buf := append([]byte{0x00, 0x11, 0x22}, 0xAA, 0xC0, 0x23, 0x01, 0x55)
+
+	frm, consumed, err := framer.Decode(buf)
+	if err != nil {
+		t.Fatalf("Decode returned error: %v", err)
+	}
+
+	if frm != nil || consumed != 3 {
+		t.Fatalf("Decode = (%+v, %d), want (nil, 3)", frm, consumed)
+	}
+}
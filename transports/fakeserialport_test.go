@@ -0,0 +1,98 @@
+package transports
+
+import (
+	"io"
+	"sync"
+	"time"
+)
+
+// fakeSerialPort is an in-memory SerialPort that replays a captured byte
+// stream on Read and records everything written to it, so transports can be
+// exercised deterministically without a physical adapter
+type fakeSerialPort struct {
+	mutex sync.Mutex
+
+	toRead  []byte
+	written []byte
+	closed  bool
+
+	// repeat makes Read recycle toRead forever instead of returning io.EOF
+	// once exhausted, simulating an adapter that keeps producing frames
+	repeat bool
+
+	// readDelay, if set, is slept through before every Read returns,
+	// simulating a read goroutine that is slow to notice cancellation
+	readDelay time.Duration
+
+	// readStarted, if set, is closed the first time Read is called, letting
+	// a test wait for the read goroutine to actually be in flight before
+	// racing it against Shutdown
+	readStarted chan struct{}
+	readOnce    sync.Once
+}
+
+func (p *fakeSerialPort) Read(b []byte) (int, error) {
+	if p.readStarted != nil {
+		p.readOnce.Do(func() { close(p.readStarted) })
+	}
+
+	if d := p.readDelay; d > 0 {
+		time.Sleep(d)
+	}
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if len(p.toRead) == 0 {
+		if p.repeat {
+			return 0, nil
+		}
+
+		return 0, io.EOF
+	}
+
+	n := copy(b, p.toRead)
+	p.toRead = p.toRead[n:]
+
+	if p.repeat {
+		p.toRead = append(p.toRead, b[:n]...)
+	}
+
+	return n, nil
+}
+
+func (p *fakeSerialPort) Write(b []byte) (int, error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	p.written = append(p.written, b...)
+
+	return len(b), nil
+}
+
+func (p *fakeSerialPort) Close() error {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	p.closed = true
+
+	return nil
+}
+
+func (p *fakeSerialPort) Drain() error { return nil }
+
+func (p *fakeSerialPort) SetReadTimeout(t time.Duration) error { return nil }
+
+func (p *fakeSerialPort) isClosed() bool {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	return p.closed
+}
+
+func (p *fakeSerialPort) writtenBytes() []byte {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	return append([]byte(nil), p.written...)
+}
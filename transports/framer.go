@@ -0,0 +1,21 @@
+package transports
+
+import "github.com/jaster-prj/go-can"
+
+// Framer encodes a can.Frame to the bytes written on the wire, and decodes
+// bytes read from the wire back into a can.Frame. It lets a transport's
+// wire framing (eg. the Canalyst binary protocol or the SLCAN ASCII
+// protocol) vary independently of how the transport manages the underlying
+// connection.
+type Framer interface {
+	// Encode serializes frm for transmission
+	Encode(frm *can.Frame) ([]byte, error)
+
+	// Decode looks for a single frame at the front of buf. If buf does not
+	// yet contain a full frame, it returns a nil frame and consumed == 0 so
+	// the caller can wait for more data. consumed is always the number of
+	// bytes that can be dropped from the front of buf, even when err != nil
+	// or no frame was found, so the caller can resynchronize on malformed
+	// input.
+	Decode(buf []byte) (frame *can.Frame, consumed int, err error)
+}
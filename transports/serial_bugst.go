@@ -0,0 +1,50 @@
+//go:build !serial_tarm
+
+package transports
+
+import (
+	"time"
+
+	"go.bug.st/serial"
+)
+
+// OpenSerialPort opens cfg using go.bug.st/serial, the default backend.
+// Build with the "serial_tarm" tag to use the legacy angelodlfrtr/serial
+// backend instead
+func OpenSerialPort(cfg SerialConfig) (SerialPort, error) {
+	dataBits := cfg.DataBits
+	if dataBits == 0 {
+		dataBits = 8
+	}
+
+	stopBits := serial.OneStopBit
+	if cfg.StopBits == 2 {
+		stopBits = serial.TwoStopBits
+	}
+
+	port, err := serial.Open(cfg.Port, &serial.Mode{
+		BaudRate: cfg.BaudRate,
+		DataBits: dataBits,
+		StopBits: stopBits,
+		Parity:   serial.NoParity,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &bugstSerialPort{port: port}, nil
+}
+
+// bugstSerialPort adapts go.bug.st/serial.Port to SerialPort
+type bugstSerialPort struct {
+	port serial.Port
+}
+
+func (p *bugstSerialPort) Read(b []byte) (int, error)  { return p.port.Read(b) }
+func (p *bugstSerialPort) Write(b []byte) (int, error) { return p.port.Write(b) }
+func (p *bugstSerialPort) Close() error                { return p.port.Close() }
+func (p *bugstSerialPort) Drain() error                { return p.port.Drain() }
+
+func (p *bugstSerialPort) SetReadTimeout(t time.Duration) error {
+	return p.port.SetReadTimeout(t)
+}
@@ -0,0 +1,77 @@
+//go:build serial_tarm
+
+package transports
+
+import (
+	"time"
+
+	"github.com/angelodlfrtr/serial"
+)
+
+// OpenSerialPort opens cfg using the legacy angelodlfrtr/serial backend,
+// selected by building with the "serial_tarm" tag. That fork is unmaintained
+// and kept only for callers who can't yet move off it; go.bug.st/serial
+// (serial_bugst.go) is the default
+func OpenSerialPort(cfg SerialConfig) (SerialPort, error) {
+	dataBits := byte(cfg.DataBits)
+	if dataBits == 0 {
+		dataBits = 8
+	}
+
+	stopBits := serial.Stop1
+	if cfg.StopBits == 2 {
+		stopBits = serial.Stop2
+	}
+
+	sc := serial.Config{
+		Name:     cfg.Port,
+		Baud:     cfg.BaudRate,
+		Size:     dataBits,
+		StopBits: stopBits,
+		Parity:   serial.ParityNone,
+	}
+
+	port, err := serial.OpenPort(&sc)
+	if err != nil {
+		return nil, err
+	}
+
+	return &tarmSerialPort{cfg: sc, port: port}, nil
+}
+
+// tarmSerialPort adapts angelodlfrtr/serial.Port to SerialPort
+type tarmSerialPort struct {
+	// cfg is kept so SetReadTimeout can reopen the port : angelodlfrtr/serial
+	// only applies Config.ReadTimeout at open time, it can't be changed on a
+	// live port
+	cfg  serial.Config
+	port *serial.Port
+}
+
+func (p *tarmSerialPort) Read(b []byte) (int, error)  { return p.port.Read(b) }
+func (p *tarmSerialPort) Write(b []byte) (int, error) { return p.port.Write(b) }
+func (p *tarmSerialPort) Close() error                { return p.port.Close() }
+func (p *tarmSerialPort) Drain() error                { return p.port.Flush() }
+
+func (p *tarmSerialPort) SetReadTimeout(t time.Duration) error {
+	if p.cfg.ReadTimeout == t {
+		return nil
+	}
+
+	if err := p.port.Close(); err != nil {
+		return err
+	}
+
+	cfg := p.cfg
+	cfg.ReadTimeout = t
+
+	port, err := serial.OpenPort(&cfg)
+	if err != nil {
+		return err
+	}
+
+	p.cfg = cfg
+	p.port = port
+
+	return nil
+}
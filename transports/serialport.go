@@ -0,0 +1,41 @@
+package transports
+
+import "time"
+
+// SerialPort is the minimal serial port behavior USBCanAnalyzer and
+// SLCANTransport need. It lets either transport be driven by different
+// backends (go.bug.st/serial by default, the legacy angelodlfrtr/serial
+// behind the "serial_tarm" build tag) or by a fake port in tests, instead of
+// depending on a concrete driver type directly
+type SerialPort interface {
+	Read(p []byte) (n int, err error)
+	Write(p []byte) (n int, err error)
+	Close() error
+
+	// SetReadTimeout bounds subsequent Read calls so the transport's read
+	// goroutine can notice context cancellation instead of blocking forever
+	SetReadTimeout(t time.Duration) error
+
+	// Drain blocks until all data written to the port has been transmitted
+	Drain() error
+}
+
+// SerialConfig holds the parameters needed to open a SerialPort
+type SerialConfig struct {
+	// Port is the serial port eg : COM0 on windows, /dev/ttyUSB0 on posix, etc
+	Port string
+
+	// BaudRate is the serial connection baud rate
+	BaudRate int
+
+	// DataBits is the number of data bits per frame. 0 defaults to 8
+	DataBits int
+
+	// StopBits is the number of stop bits. 0 defaults to 1
+	StopBits int
+}
+
+// SerialOpener opens a SerialPort for cfg. Transports default to
+// OpenSerialPort (the backend selected at build time) but accept an
+// alternate opener, eg. to inject a fake port in tests
+type SerialOpener func(cfg SerialConfig) (SerialPort, error)
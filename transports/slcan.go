@@ -0,0 +1,274 @@
+package transports
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/jaster-prj/go-can"
+)
+
+// slcanReadTimeout bounds each serial read so the read goroutine revisits
+// ctx.Done() instead of blocking on client.Read forever
+const slcanReadTimeout = 200 * time.Millisecond
+
+// slcanMaxDataBufLen caps dataBuf so a desynced stream (a line that never
+// ends in \r) can't grow it unboundedly
+const slcanMaxDataBufLen = 4096
+
+// slcanBitrateCodes maps a CAN bitrate to the Lawicel/SLCAN "Sx" speed
+// command code
+var slcanBitrateCodes = map[int]byte{
+	10000:   '0',
+	20000:   '1',
+	50000:   '2',
+	100000:  '3',
+	125000:  '4',
+	250000:  '5',
+	500000:  '6',
+	800000:  '7',
+	1000000: '8',
+}
+
+// ErrUnsupportedBitrate is returned when a Bitrate has no matching SLCAN "Sx"
+// command code
+var ErrUnsupportedBitrate = errors.New("transports: unsupported slcan bitrate")
+
+// SLCANTransport define a connection to a Lawicel/SLCAN compatible adapter
+// (canusb, USBtin, CANable, etc) via a serial connection
+type SLCANTransport struct {
+	// Port is the serial port eg : COM0 on windows, /dev/ttyUSB0 on posix, etc
+	Port string
+
+	// BaudRate is the serial connection baud rate. Most SLCAN adapters use
+	// a fixed rate (eg. 115200) independent of the CAN bus bitrate
+	BaudRate int
+
+	// Bitrate is the CAN bus bitrate, sent to the adapter as an "Sx" command
+	// when opening the connection
+	Bitrate int
+
+	// Framer encodes/decodes frames to/from the wire. Defaults to
+	// SLCANFramer if left nil
+	Framer Framer
+
+	// Opener opens the serial connection. Defaults to OpenSerialPort, the
+	// backend selected at build time; tests can inject a fake SerialPort
+	// here instead of talking to a physical adapter
+	Opener SerialOpener
+
+	// client is the SerialPort instance
+	client SerialPort
+
+	// dataBuf contain data received by serial connection
+	dataBuf []byte
+
+	// mutex to access dataBuf
+	mutex sync.Mutex
+
+	// readErr holds the last error encountered reading the serial
+	// connection, if any
+	readErr atomic.Value
+
+	// cancel stops the read goroutine started by Open
+	cancel context.CancelFunc
+
+	// wg is done once the read goroutine has returned
+	wg sync.WaitGroup
+
+	// readChan is a chan for reading frames
+	readChan chan *can.Frame
+}
+
+func (t *SLCANTransport) run(ctx context.Context) {
+	t.readChan = make(chan *can.Frame)
+	t.wg.Add(1)
+
+	go func() {
+		defer t.wg.Done()
+		defer close(t.readChan)
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			default:
+			}
+
+			// 64 byte read buffer
+			data := make([]byte, 64)
+
+			// Read data, bounded by slcanReadTimeout so we come back
+			// around to check ctx.Done()
+			n, err := t.client.Read(data)
+
+			if errors.Is(err, io.EOF) {
+				continue
+			}
+
+			if err != nil {
+				t.readErr.Store(err)
+				continue
+			}
+
+			// Append to global data buf, capping it so a desynced stream
+			// can't grow it forever
+			t.mutex.Lock()
+			t.dataBuf = append(t.dataBuf, data[:n]...)
+			if len(t.dataBuf) > slcanMaxDataBufLen {
+				t.dataBuf = t.dataBuf[len(t.dataBuf)-slcanMaxDataBufLen:]
+			}
+			t.mutex.Unlock()
+
+			// Publish frames on channel
+			for {
+				if ok := t.publishFrames(ctx); !ok {
+					break
+				}
+			}
+		}
+	}()
+}
+
+func (t *SLCANTransport) publishFrames(ctx context.Context) bool {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	frm, consumed, err := t.Framer.Decode(t.dataBuf)
+
+	if consumed > 0 {
+		t.dataBuf = t.dataBuf[consumed:]
+	}
+
+	if err != nil || frm == nil {
+		return false
+	}
+
+	// Publish frame, bailing out if ctx is done so a consumer that stopped
+	// draining ReadChan can't block this send forever
+	select {
+	case t.readChan <- frm:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// Open a serial connection. The read goroutine stops once ctx is done
+// See https://www.can232.com/docs/canusb_manual.pdf for protocol definition
+func (t *SLCANTransport) Open(ctx context.Context) error {
+	bitrateCode, ok := slcanBitrateCodes[t.Bitrate]
+	if !ok {
+		return ErrUnsupportedBitrate
+	}
+
+	if t.Opener == nil {
+		t.Opener = OpenSerialPort
+	}
+
+	port, err := t.Opener(SerialConfig{
+		Port:     t.Port,
+		BaudRate: t.BaudRate,
+	})
+	if err != nil {
+		return err
+	}
+
+	// Bound client.Read so the read goroutine can notice ctx cancellation
+	// instead of blocking forever
+	if err := port.SetReadTimeout(slcanReadTimeout); err != nil {
+		port.Close()
+		return err
+	}
+
+	t.client = port
+
+	if t.Framer == nil {
+		t.Framer = &SLCANFramer{}
+	}
+
+	// Set bitrate, then open the CAN channel
+	if _, err := t.client.Write([]byte(fmt.Sprintf("S%c\r", bitrateCode))); err != nil {
+		return err
+	}
+
+	if _, err := t.client.Write([]byte("O\r")); err != nil {
+		return err
+	}
+
+	// Run reads from serial
+	runCtx, cancel := context.WithCancel(ctx)
+	t.cancel = cancel
+	t.run(runCtx)
+
+	return nil
+}
+
+// Shutdown closes the CAN channel, stops the read goroutine and closes the
+// serial connection, waiting for the read goroutine to actually return until
+// ctx is done
+func (t *SLCANTransport) Shutdown(ctx context.Context) error {
+	if t.client == nil {
+		return nil
+	}
+
+	// Close the CAN channel before tearing down the serial link
+	if _, err := t.client.Write([]byte("C\r")); err != nil {
+		return err
+	}
+
+	// Stop the read goroutine; it closes readChan itself once it returns
+	t.cancel()
+
+	done := make(chan struct{})
+	go func() {
+		t.wg.Wait()
+		close(done)
+	}()
+
+	// Close the port unconditionally, even if ctx expires before the read
+	// goroutine returns, so it's never leaked for the rest of the process
+	var waitErr error
+	select {
+	case <-done:
+	case <-ctx.Done():
+		waitErr = ctx.Err()
+	}
+
+	// Prefer surfacing waitErr (ctx expiring) over a close error, since
+	// callers check it with errors.Is(err, context.DeadlineExceeded) to
+	// detect the read goroutine not having stopped in time
+	closeErr := t.client.Close()
+	if waitErr != nil {
+		return waitErr
+	}
+
+	return closeErr
+}
+
+// ReadErr returns the last error encountered reading the serial connection,
+// if any
+func (t *SLCANTransport) ReadErr() error {
+	err, _ := t.readErr.Load().(error)
+	return err
+}
+
+// Write a frame to serial connection
+func (t *SLCANTransport) Write(frm *can.Frame) error {
+	data, err := t.Framer.Encode(frm)
+	if err != nil {
+		return err
+	}
+
+	_, err = t.client.Write(data)
+	return err
+}
+
+// ReadChan returns the read chan
+func (t *SLCANTransport) ReadChan() chan *can.Frame {
+	return t.readChan
+}
@@ -0,0 +1,122 @@
+package transports
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/jaster-prj/go-can"
+)
+
+// errTruncatedSLCANFrame is returned by SLCANFramer.Decode when a line ends
+// (\r found) before all of its expected fields are present
+var errTruncatedSLCANFrame = errors.New("transports: truncated slcan frame")
+
+// errBadSLCANDLC is returned by SLCANFramer.Decode when the parsed DLC digit
+// exceeds 8, the maximum a Frame.Data array can hold
+var errBadSLCANDLC = errors.New("transports: slcan frame DLC exceeds 8")
+
+// SLCANFramer implements Framer for the Lawicel/SLCAN ASCII protocol spoken
+// by canusb, USBtin, CANable and similar adapters: "t<id><dlc><data>\r" for
+// standard data frames, "T<id><dlc><data>\r" for extended, and "r"/"R" for
+// their remote-transmission-request counterparts
+type SLCANFramer struct{}
+
+// Encode a frame into an SLCAN ASCII command line
+func (f *SLCANFramer) Encode(frm *can.Frame) ([]byte, error) {
+	var cmd byte
+	idDigits := 3
+
+	switch {
+	case frm.Extended && frm.RTR:
+		cmd, idDigits = 'R', 8
+	case frm.Extended:
+		cmd, idDigits = 'T', 8
+	case frm.RTR:
+		cmd = 'r'
+	default:
+		cmd = 't'
+	}
+
+	line := fmt.Sprintf("%c%0*X%X", cmd, idDigits, frm.ArbitrationID, frm.DLC)
+
+	if !frm.RTR {
+		line += strings.ToUpper(hex.EncodeToString(frm.Data[:frm.DLC]))
+	}
+
+	return []byte(line + "\r"), nil
+}
+
+// Decode a single SLCAN command line from the front of buf
+func (f *SLCANFramer) Decode(buf []byte) (*can.Frame, int, error) {
+	idx := bytes.IndexByte(buf, '\r')
+	if idx == -1 {
+		return nil, 0, nil
+	}
+
+	consumed := idx + 1
+	line := buf[:idx]
+
+	if len(line) == 0 {
+		return nil, consumed, nil
+	}
+
+	frm := &can.Frame{}
+	idDigits := 3
+
+	switch line[0] {
+	case 't':
+	case 'T':
+		frm.Extended = true
+		idDigits = 8
+	case 'r':
+		frm.RTR = true
+	case 'R':
+		frm.Extended = true
+		frm.RTR = true
+		idDigits = 8
+	default:
+		// Not a data frame line (command ack/nack, status report, etc); ignore it
+		return nil, consumed, nil
+	}
+
+	if len(line) < 1+idDigits+1 {
+		return nil, consumed, errTruncatedSLCANFrame
+	}
+
+	id, err := strconv.ParseUint(string(line[1:1+idDigits]), 16, 32)
+	if err != nil {
+		return nil, consumed, err
+	}
+
+	dlc, err := strconv.ParseUint(string(line[1+idDigits:2+idDigits]), 16, 8)
+	if err != nil {
+		return nil, consumed, err
+	}
+
+	if dlc > 8 {
+		return nil, consumed, errBadSLCANDLC
+	}
+
+	frm.ArbitrationID = uint32(id)
+	frm.DLC = uint8(dlc)
+
+	if !frm.RTR {
+		dataHex := line[2+idDigits:]
+		if len(dataHex) < int(frm.DLC)*2 {
+			return nil, consumed, errTruncatedSLCANFrame
+		}
+
+		data, err := hex.DecodeString(string(dataHex[:int(frm.DLC)*2]))
+		if err != nil {
+			return nil, consumed, err
+		}
+
+		copy(frm.Data[:frm.DLC], data)
+	}
+
+	return frm, consumed, nil
+}
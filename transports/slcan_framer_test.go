@@ -0,0 +1,162 @@
+package transports
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/jaster-prj/go-can"
+)
+
+func TestSLCANFramerEncodeDecode(t *testing.T) {
+	tests := []struct {
+		name  string
+		frm   *can.Frame
+		bytes []byte
+	}{
+		{
+			name: "standard data frame",
+			frm: &can.Frame{
+				ArbitrationID: 0x123,
+				DLC:           3,
+				Data:          [8]byte{0x01, 0x02, 0x03},
+			},
+			bytes: []byte("t1233010203\r"),
+		},
+		{
+			name: "standard RTR frame",
+			frm: &can.Frame{
+				ArbitrationID: 0x123,
+				DLC:           0,
+				RTR:           true,
+			},
+			bytes: []byte("r1230\r"),
+		},
+		{
+			name: "extended data frame",
+			frm: &can.Frame{
+				ArbitrationID: 0x1ABCDE,
+				DLC:           2,
+				Data:          [8]byte{0xAA, 0xBB},
+				Extended:      true,
+			},
+			bytes: []byte("T001ABCDE2AABB\r"),
+		},
+		{
+			name: "extended RTR frame",
+			frm: &can.Frame{
+				ArbitrationID: 0x1ABCDE,
+				DLC:           0,
+				Extended:      true,
+				RTR:           true,
+			},
+			bytes: []byte("R001ABCDE0\r"),
+		},
+	}
+
+	framer := &SLCANFramer{}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			encoded, err := framer.Encode(tt.frm)
+			if err != nil {
+				t.Fatalf("Encode returned error: %v", err)
+			}
+
+			if !bytes.Equal(encoded, tt.bytes) {
+				t.Fatalf("Encode = %q, want %q", encoded, tt.bytes)
+			}
+
+			decoded, consumed, err := framer.Decode(tt.bytes)
+			if err != nil {
+				t.Fatalf("Decode returned error: %v", err)
+			}
+
+			if consumed != len(tt.bytes) {
+				t.Fatalf("Decode consumed = %d, want %d", consumed, len(tt.bytes))
+			}
+
+			if *decoded != *tt.frm {
+				t.Fatalf("Decode = %+v, want %+v", decoded, tt.frm)
+			}
+		})
+	}
+}
+
+func TestSLCANFramerDecodeIncomplete(t *testing.T) {
+	framer := &SLCANFramer{}
+
+	// No \r yet; must wait for more data
+	buf := []byte("t1233010203")
+
+	frm, consumed, err := framer.Decode(buf)
+	if err != nil {
+		t.Fatalf("Decode returned error: %v", err)
+	}
+
+	if frm != nil || consumed != 0 {
+		t.Fatalf("Decode = (%+v, %d), want (nil, 0)", frm, consumed)
+	}
+}
+
+func TestSLCANFramerDecodeTruncatedHeader(t *testing.T) {
+	framer := &SLCANFramer{}
+
+	buf := []byte("t12\r")
+
+	frm, consumed, err := framer.Decode(buf)
+	if err != errTruncatedSLCANFrame {
+		t.Fatalf("Decode err = %v, want errTruncatedSLCANFrame", err)
+	}
+
+	if frm != nil || consumed != len(buf) {
+		t.Fatalf("Decode = (%+v, %d), want (nil, %d)", frm, consumed, len(buf))
+	}
+}
+
+func TestSLCANFramerDecodeTruncatedData(t *testing.T) {
+	framer := &SLCANFramer{}
+
+	// DLC says 3 data bytes but only 1 is present
+	buf := []byte("t123301\r")
+
+	frm, consumed, err := framer.Decode(buf)
+	if err != errTruncatedSLCANFrame {
+		t.Fatalf("Decode err = %v, want errTruncatedSLCANFrame", err)
+	}
+
+	if frm != nil || consumed != len(buf) {
+		t.Fatalf("Decode = (%+v, %d), want (nil, %d)", frm, consumed, len(buf))
+	}
+}
+
+func TestSLCANFramerDecodeOverlongDLC(t *testing.T) {
+	framer := &SLCANFramer{}
+
+	// DLC digit of 9 exceeds the 8-byte Data array
+	buf := []byte("t1239010203040506070809\r")
+
+	frm, consumed, err := framer.Decode(buf)
+	if err != errBadSLCANDLC {
+		t.Fatalf("Decode err = %v, want errBadSLCANDLC", err)
+	}
+
+	if frm != nil || consumed != len(buf) {
+		t.Fatalf("Decode = (%+v, %d), want (nil, %d)", frm, consumed, len(buf))
+	}
+}
+
+func TestSLCANFramerDecodeIgnoresNonDataLine(t *testing.T) {
+	framer := &SLCANFramer{}
+
+	// "z\r" is a command ack, not a data frame line
+	buf := []byte("z\r")
+
+	frm, consumed, err := framer.Decode(buf)
+	if err != nil {
+		t.Fatalf("Decode returned error: %v", err)
+	}
+
+	if frm != nil || consumed != len(buf) {
+		t.Fatalf("Decode = (%+v, %d), want (nil, %d)", frm, consumed, len(buf))
+	}
+}
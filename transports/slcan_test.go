@@ -0,0 +1,154 @@
+package transports
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jaster-prj/go-can"
+)
+
+// TestSLCANTransportOpenDecodesFrame opens an SLCANTransport against a fake
+// port preloaded with a captured SLCAN data line and checks it comes out the
+// other end of ReadChan decoded
+func TestSLCANTransportOpenDecodesFrame(t *testing.T) {
+	framer := &SLCANFramer{}
+
+	want := &can.Frame{ArbitrationID: 0x123, DLC: 3, Data: [8]byte{0x01, 0x02, 0x03}}
+
+	captured, err := framer.Encode(want)
+	if err != nil {
+		t.Fatalf("Encode returned error: %v", err)
+	}
+
+	port := &fakeSerialPort{toRead: captured}
+	tr := &SLCANTransport{Bitrate: 500000, Opener: func(cfg SerialConfig) (SerialPort, error) { return port, nil }}
+
+	assertOpenDecodesFrame(t, tr, want)
+}
+
+// TestSLCANTransportOpenUnsupportedBitrate checks that Open rejects a
+// bitrate with no matching SLCAN "Sx" command code
+func TestSLCANTransportOpenUnsupportedBitrate(t *testing.T) {
+	tr := &SLCANTransport{Bitrate: 42}
+
+	if err := tr.Open(context.Background()); err != ErrUnsupportedBitrate {
+		t.Fatalf("Open err = %v, want ErrUnsupportedBitrate", err)
+	}
+}
+
+// TestSLCANTransportOpenWritesBitrateAndOpenCommands checks that Open sends
+// the "Sx" speed command followed by "O" to open the CAN channel
+func TestSLCANTransportOpenWritesBitrateAndOpenCommands(t *testing.T) {
+	port := &fakeSerialPort{}
+
+	tr := &SLCANTransport{Bitrate: 500000, Opener: func(cfg SerialConfig) (SerialPort, error) { return port, nil }}
+
+	if err := tr.Open(context.Background()); err != nil {
+		t.Fatalf("Open returned error: %v", err)
+	}
+	defer tr.Shutdown(context.Background())
+
+	want := []byte("S6\rO\r")
+	if got := port.writtenBytes(); !bytes.Equal(got, want) {
+		t.Fatalf("Open wrote % X, want % X", got, want)
+	}
+}
+
+// TestSLCANTransportShutdownClosesPort checks that Shutdown closes the CAN
+// channel and the underlying port once opened
+func TestSLCANTransportShutdownClosesPort(t *testing.T) {
+	port := &fakeSerialPort{}
+	tr := &SLCANTransport{Bitrate: 500000, Opener: func(cfg SerialConfig) (SerialPort, error) { return port, nil }}
+
+	assertShutdownClosesPort(t, tr, port)
+
+	if got := port.writtenBytes(); !bytes.HasSuffix(got, []byte("C\r")) {
+		t.Fatalf("Shutdown did not write the close-channel command; got % X", got)
+	}
+}
+
+// TestSLCANTransportShutdownWithoutDrainingReadChan opens an SLCANTransport
+// against a port that keeps producing valid frames and shuts it down without
+// ever reading ReadChan(), the normal "stop reading, then tear down"
+// sequence. Shutdown must still close the port instead of leaking the read
+// goroutine blocked on the readChan send
+func TestSLCANTransportShutdownWithoutDrainingReadChan(t *testing.T) {
+	framer := &SLCANFramer{}
+
+	captured, err := framer.Encode(&can.Frame{ArbitrationID: 0x123, DLC: 3, Data: [8]byte{0x01, 0x02, 0x03}})
+	if err != nil {
+		t.Fatalf("Encode returned error: %v", err)
+	}
+
+	port := &fakeSerialPort{toRead: captured, repeat: true}
+	tr := &SLCANTransport{Bitrate: 500000, Opener: func(cfg SerialConfig) (SerialPort, error) { return port, nil }}
+
+	assertShutdownWithoutDrainingReadChan(t, tr, port)
+}
+
+// TestSLCANTransportShutdownClosesPortDespiteSlowReadGoroutine checks that
+// Shutdown closes the port even when its ctx expires before the read
+// goroutine notices cancellation, instead of leaking the port for the rest
+// of the process
+func TestSLCANTransportShutdownClosesPortDespiteSlowReadGoroutine(t *testing.T) {
+	port := &fakeSerialPort{repeat: true, readDelay: 200 * time.Millisecond, readStarted: make(chan struct{})}
+	tr := &SLCANTransport{Bitrate: 500000, Opener: func(cfg SerialConfig) (SerialPort, error) { return port, nil }}
+
+	assertShutdownClosesPortDespiteSlowReadGoroutine(t, tr, port)
+}
+
+// TestSLCANTransportDataBufCap exercises the dataBuf append+cap path under
+// -race through the real Open/run()/publishFrames code path: a continuous
+// stream of bytes that never contains a \r line terminator must still leave
+// dataBuf capped at slcanMaxDataBufLen instead of growing unboundedly
+func TestSLCANTransportDataBufCap(t *testing.T) {
+	port := &fakeSerialPort{toRead: bytes.Repeat([]byte{'X'}, 64), repeat: true}
+	tr := &SLCANTransport{Bitrate: 500000, Opener: func(cfg SerialConfig) (SerialPort, error) { return port, nil }}
+
+	if err := tr.Open(context.Background()); err != nil {
+		t.Fatalf("Open returned error: %v", err)
+	}
+	defer tr.Shutdown(context.Background())
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		tr.mutex.Lock()
+		n := len(tr.dataBuf)
+		tr.mutex.Unlock()
+
+		if n > slcanMaxDataBufLen {
+			t.Fatalf("dataBuf len = %d, want <= %d", n, slcanMaxDataBufLen)
+		}
+
+		if n == slcanMaxDataBufLen {
+			break
+		}
+
+		if time.Now().After(deadline) {
+			t.Fatalf("dataBuf never reached slcanMaxDataBufLen, got %d", n)
+		}
+
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// TestSLCANTransportOpenShutdownLoop opens and shuts down many
+// SLCANTransports concurrently against ports that keep producing valid
+// frames, exercising the real run()/publishFrames lifecycle under -race
+func TestSLCANTransportOpenShutdownLoop(t *testing.T) {
+	framer := &SLCANFramer{}
+
+	captured, err := framer.Encode(&can.Frame{ArbitrationID: 0x123, DLC: 3, Data: [8]byte{0x01, 0x02, 0x03}})
+	if err != nil {
+		t.Fatalf("Encode returned error: %v", err)
+	}
+
+	assertOpenShutdownLoop(t, 50, func() (lifecycleTransport, *fakeSerialPort) {
+		port := &fakeSerialPort{toRead: captured, repeat: true}
+		tr := &SLCANTransport{Bitrate: 500000, Opener: func(cfg SerialConfig) (SerialPort, error) { return port, nil }}
+
+		return tr, port
+	})
+}
@@ -0,0 +1,171 @@
+package transports
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jaster-prj/go-can"
+)
+
+// lifecycleTransport is the subset of USBCanAnalyzer's and SLCANTransport's
+// methods the shared lifecycle test helpers below drive. Both transports
+// implement it structurally, which lets the tests in
+// usbcananalyzer_opener_test.go/usbcananalyzer_lifecycle_test.go and
+// slcan_test.go share one set of assertions instead of carrying near-
+// identical copies
+type lifecycleTransport interface {
+	Open(ctx context.Context) error
+	Shutdown(ctx context.Context) error
+	ReadChan() chan *can.Frame
+}
+
+// assertOpenDecodesFrame opens tr (already wired to a fake port preloaded
+// with the wire encoding of want) and checks want comes out the other end of
+// ReadChan decoded
+func assertOpenDecodesFrame(t *testing.T, tr lifecycleTransport, want *can.Frame) {
+	t.Helper()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	if err := tr.Open(ctx); err != nil {
+		t.Fatalf("Open returned error: %v", err)
+	}
+
+	select {
+	case got := <-tr.ReadChan():
+		if got.ArbitrationID != want.ArbitrationID || got.DLC != want.DLC || got.Data != want.Data {
+			t.Fatalf("got frame %+v, want %+v", got, want)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for decoded frame")
+	}
+
+	if err := tr.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown returned error: %v", err)
+	}
+}
+
+// assertShutdownUnopened checks that Shutdown is a safe no-op when the
+// transport was never opened
+func assertShutdownUnopened(t *testing.T, tr lifecycleTransport) {
+	t.Helper()
+
+	if err := tr.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown returned error: %v", err)
+	}
+}
+
+// assertShutdownClosesPort opens tr against port, shuts it down and checks
+// port was closed
+func assertShutdownClosesPort(t *testing.T, tr lifecycleTransport, port *fakeSerialPort) {
+	t.Helper()
+
+	if err := tr.Open(context.Background()); err != nil {
+		t.Fatalf("Open returned error: %v", err)
+	}
+
+	if err := tr.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown returned error: %v", err)
+	}
+
+	if !port.isClosed() {
+		t.Fatal("Shutdown did not close the underlying port")
+	}
+}
+
+// assertShutdownWithoutDrainingReadChan opens tr against a port that keeps
+// producing valid frames and shuts it down without ever reading ReadChan(),
+// the normal "stop reading, then tear down" sequence. Shutdown must still
+// close the port instead of leaking the read goroutine blocked on the
+// readChan send
+func assertShutdownWithoutDrainingReadChan(t *testing.T, tr lifecycleTransport, port *fakeSerialPort) {
+	t.Helper()
+
+	if err := tr.Open(context.Background()); err != nil {
+		t.Fatalf("Open returned error: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	if err := tr.Shutdown(ctx); err != nil {
+		t.Fatalf("Shutdown returned error: %v", err)
+	}
+
+	if !port.isClosed() {
+		t.Fatal("Shutdown did not close the underlying port")
+	}
+}
+
+// assertShutdownClosesPortDespiteSlowReadGoroutine opens tr against port
+// (configured with a readDelay longer than Shutdown's ctx timeout and a
+// readStarted chan) and checks that Shutdown still closes port even though
+// it gives up waiting on the read goroutine and returns ctx.Err() first
+func assertShutdownClosesPortDespiteSlowReadGoroutine(t *testing.T, tr lifecycleTransport, port *fakeSerialPort) {
+	t.Helper()
+
+	if err := tr.Open(context.Background()); err != nil {
+		t.Fatalf("Open returned error: %v", err)
+	}
+
+	// Wait for the read goroutine to actually be blocked in Read, so
+	// Shutdown races a goroutine known to be slow instead of one that
+	// hasn't started its first read yet
+	select {
+	case <-port.readStarted:
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for the read goroutine to start reading")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	if err := tr.Shutdown(ctx); err != context.DeadlineExceeded {
+		t.Fatalf("Shutdown err = %v, want context.DeadlineExceeded", err)
+	}
+
+	if !port.isClosed() {
+		t.Fatal("Shutdown did not close the underlying port despite the read goroutine not yet having returned")
+	}
+}
+
+// assertOpenShutdownLoop repeatedly opens and shuts down transports built by
+// newTransport concurrently, driving the real run()/publishFrames path
+// (including the dataBuf append+cap logic) under -race
+func assertOpenShutdownLoop(t *testing.T, loops int, newTransport func() (lifecycleTransport, *fakeSerialPort)) {
+	t.Helper()
+
+	var wg sync.WaitGroup
+
+	for i := 0; i < loops; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			tr, port := newTransport()
+
+			if err := tr.Open(context.Background()); err != nil {
+				t.Errorf("Open returned error: %v", err)
+				return
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+			defer cancel()
+
+			if err := tr.Shutdown(ctx); err != nil {
+				t.Errorf("Shutdown returned error: %v", err)
+				return
+			}
+
+			if !port.isClosed() {
+				t.Error("Shutdown did not close the underlying port")
+			}
+		}()
+	}
+
+	wg.Wait()
+}
@@ -1,16 +1,24 @@
 package transports
 
 import (
-	"encoding/binary"
+	"context"
 	"errors"
 	"io"
 	"sync"
+	"sync/atomic"
 	"time"
 
-	"github.com/angelodlfrtr/serial"
 	"github.com/jaster-prj/go-can"
 )
 
+// usbCanAnalyzerReadTimeout bounds each serial read so the read goroutine
+// revisits ctx.Done() instead of blocking on client.Read forever
+const usbCanAnalyzerReadTimeout = 200 * time.Millisecond
+
+// usbCanAnalyzerMaxDataBufLen caps dataBuf so a desynced stream (a stray SOF
+// never followed by a complete frame) can't grow it unboundedly
+const usbCanAnalyzerMaxDataBufLen = 4096
+
 // USBCanAnalyzer define a USBCanAnalyzer connection to canbus via serial connection on USB
 type USBCanAnalyzer struct {
 	// Port is the serial port eg : COM0 on windows, /dev/ttytest on posix, etc
@@ -19,8 +27,22 @@ type USBCanAnalyzer struct {
 	// BaudRate is the serial connection baud rate
 	BaudRate int
 
-	// client is the serial.Port instance
-	client *serial.Port
+	// Framer encodes/decodes frames to/from the wire. Defaults to
+	// CanalystBinaryFramer if left nil
+	Framer Framer
+
+	// Config holds the adapter's CAN bus parameters (bitrate, acceptance
+	// filter, mode, ...). Defaults to DefaultUSBCanAnalyzerConfig if left
+	// at its zero value
+	Config USBCanAnalyzerConfig
+
+	// Opener opens the serial connection. Defaults to OpenSerialPort, the
+	// backend selected at build time; tests can inject a fake SerialPort
+	// here instead of talking to a physical adapter
+	Opener SerialOpener
+
+	// client is the SerialPort instance
+	client SerialPort
 
 	// dataBuf contain data received by serial connection
 	dataBuf []byte
@@ -28,54 +50,63 @@ type USBCanAnalyzer struct {
 	// mutex to access dataBuf
 	mutex sync.Mutex
 
-	// readErr is set if listen encounter an error during the read, readErr is set
-	readErr error
+	// readErr holds the last error encountered reading the serial
+	// connection, if any
+	readErr atomic.Value
 
-	// running is read goroutine running
-	running bool
+	// cancel stops the read goroutine started by Open
+	cancel context.CancelFunc
+
+	// wg is done once the read goroutine has returned
+	wg sync.WaitGroup
 
 	// readChan is a chan for reading frames
 	readChan chan *can.Frame
 }
 
-func (t *USBCanAnalyzer) run() {
-	t.running = true
+func (t *USBCanAnalyzer) run(ctx context.Context) {
 	t.readChan = make(chan *can.Frame)
+	t.wg.Add(1)
 
 	go func() {
-		for {
-			// Stop goroutine if t.running == false
-			t.mutex.Lock()
-			running := t.running
-			t.mutex.Unlock()
+		defer t.wg.Done()
+		defer close(t.readChan)
 
-			if !running {
-				break
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			default:
 			}
 
 			// 64 byte read buffer
 			data := make([]byte, 64)
 
-			// Read data (in a blocking way)
+			// Read data, bounded by usbCanAnalyzerReadTimeout so we come
+			// back around to check ctx.Done()
 			n, err := t.client.Read(data)
 
 			if errors.Is(err, io.EOF) {
 				continue
 			}
 
-			t.readErr = err
 			if err != nil {
+				t.readErr.Store(err)
 				continue
 			}
 
-			// Append to global data buf
+			// Append to global data buf, capping it so a desynced stream
+			// can't grow it forever
 			t.mutex.Lock()
 			t.dataBuf = append(t.dataBuf, data[:n]...)
+			if len(t.dataBuf) > usbCanAnalyzerMaxDataBufLen {
+				t.dataBuf = t.dataBuf[len(t.dataBuf)-usbCanAnalyzerMaxDataBufLen:]
+			}
 			t.mutex.Unlock()
 
 			// Publish frames on channel
 			for {
-				if ok := t.publishFrames(); !ok {
+				if ok := t.publishFrames(ctx); !ok {
 					break
 				}
 			}
@@ -83,128 +114,84 @@ func (t *USBCanAnalyzer) run() {
 	}()
 }
 
-func (t *USBCanAnalyzer) publishFrames() bool {
+func (t *USBCanAnalyzer) publishFrames(ctx context.Context) bool {
 	t.mutex.Lock()
 	defer t.mutex.Unlock()
 
-	// Find adapter start of frame
-	for {
-		// Stop if buffer is empty
-		if len(t.dataBuf) == 0 {
-			break
-		}
-
-		// Stop if found SOF
-		if t.dataBuf[0] == 0xAA {
-			break
-		}
+	frm, consumed, err := t.Framer.Decode(t.dataBuf)
 
-		// Remove one element from dataBuf and loop again
-		t.dataBuf = t.dataBuf[1:]
+	if consumed > 0 {
+		t.dataBuf = t.dataBuf[consumed:]
 	}
 
-	// Check if data can contain an entire frame (min frame size is 5 in case of 0 data)
-	// Else read serial
-	// (SOF + 2 + DLC + EOF) = 5
-	if len(t.dataBuf) < 5 {
+	// @TODO: Maybe surface err here instead of silently resynchronizing ?
+	if err != nil || frm == nil {
 		return false
 	}
 
-	// Create new frame
-	frm := &can.Frame{}
-
-	// DLC
-	frm.DLC = t.dataBuf[1] - 0xC0
-
-	// Check buffer len can contain a frame
-	// else read serial
-	if len(t.dataBuf) < 5+int(frm.DLC) {
+	// Publish frame, bailing out if ctx is done so a consumer that stopped
+	// draining ReadChan can't block this send forever
+	select {
+	case t.readChan <- frm:
+		return true
+	case <-ctx.Done():
 		return false
 	}
-
-	// Validate frame
-	// Check frame end with 0x55
-	// The USB cananalyzer have bug and soemtimes returns wrong data fields
-	if t.dataBuf[4+int(frm.DLC)] != 0x55 {
-		// Ignore frame by juste removing the frame SOF
-		// The frame will be ignored at next iteration
-		t.dataBuf = t.dataBuf[1:]
-
-		// @TODO: Maybe return an error here ?
-		return false
-	}
-
-	// Arbitration ID
-	frm.ArbitrationID = uint32(binary.LittleEndian.Uint16(t.dataBuf[2:]))
-
-	// Data
-	for i := 0; i < int(frm.DLC); i++ {
-		frm.Data[i] = t.dataBuf[i+4]
-	}
-
-	// Resize t.dataBuf
-	lastMsgLen := 1 + 1 + 2 + frm.DLC + 1 // 0xAA (SOF) + DLC + arbId + data + 0x55 (EOF)
-	t.dataBuf = t.dataBuf[lastMsgLen:]
-
-	// Publish frame
-	t.readChan <- frm
-
-	return true
 }
 
-// Open a serial connection
+// Open a serial connection. The read goroutine stops once ctx is done
 // Show https://github.com/kobolt/usb-can/blob/master/canusb.c for protocol definition
-func (t *USBCanAnalyzer) Open() error {
-	serialConfig := &serial.Config{
-		// Name of the serial port
-		Name: t.Port,
+func (t *USBCanAnalyzer) Open(ctx context.Context) error {
+	if t.Opener == nil {
+		t.Opener = OpenSerialPort
+	}
 
-		// Baud rate should normally be 2 000 000
-		Baud: t.BaudRate,
+	port, err := t.Opener(SerialConfig{
+		Port:     t.Port,
+		BaudRate: t.BaudRate,
+		DataBits: 8,
+		StopBits: 1,
+	})
+	if err != nil {
+		return err
+	}
 
-		// ReadTimeout for the connection. If zero, the Read() operation is blocking
-		// ReadTimeout: 100 * time.Millisecond,
-		ReadTimeout: 0,
+	// Bound client.Read so the read goroutine can notice ctx cancellation
+	// instead of blocking forever
+	if err := port.SetReadTimeout(usbCanAnalyzerReadTimeout); err != nil {
+		port.Close()
+		return err
+	}
 
-		// Size is 8 databytes for USBCanAnalyzer
-		Size: 8,
+	t.client = port
 
-		// StopBits is 1 for usbCanAnalyzer
-		StopBits: 1,
+	if t.Framer == nil {
+		t.Framer = &CanalystBinaryFramer{}
+	}
 
-		// Parity none for usbCanAnalyzer
-		Parity: serial.ParityNone,
+	if (t.Config == USBCanAnalyzerConfig{}) {
+		t.Config = DefaultUSBCanAnalyzerConfig()
 	}
 
-	port, err := serial.OpenPort(serialConfig)
-	if err != nil {
+	// Send initialization sequence (configure adapter)
+	if err := t.writeInitFrame(); err != nil {
 		return err
 	}
 
-	t.client = port
+	// Run reads from serial
+	runCtx, cancel := context.WithCancel(ctx)
+	t.cancel = cancel
+	t.run(runCtx)
 
-	// Send initialization sequence (configure adapter)
-	seq := []byte{
-		0xAA,
-		0x55,
-		0x12,
-		0x07,
-		0x01,
-		0x00,
-		0x00,
-		0x00,
-		0x00,
-		0x00,
-		0x00,
-		0x00,
-		0x00,
-		0x00,
-		0x01,
-		0x00,
-		0x00,
-		0x00,
-		0x00,
-		0x1B,
+	return nil
+}
+
+// writeInitFrame builds the init frame from t.Config and sends it to the
+// adapter
+func (t *USBCanAnalyzer) writeInitFrame() error {
+	seq, err := buildInitFrame(t.Config)
+	if err != nil {
+		return err
 	}
 
 	if _, err := t.client.Write(seq); err != nil {
@@ -214,51 +201,75 @@ func (t *USBCanAnalyzer) Open() error {
 	// Wait 500ms (else adapater crash)
 	time.Sleep(500 * time.Millisecond)
 
-	// Run reads from serial
-	t.run()
-
 	return nil
 }
 
-// Close a serial connection
-func (t *USBCanAnalyzer) Close() error {
+// SetBitrate updates the CAN bus bitrate and resends the init frame to the
+// already open adapter
+func (t *USBCanAnalyzer) SetBitrate(bitrate Bitrate) error {
+	t.Config.Bitrate = bitrate
+	return t.writeInitFrame()
+}
+
+// SetFilter updates the acceptance filter/mask and resends the init frame to
+// the already open adapter
+func (t *USBCanAnalyzer) SetFilter(filter, mask uint32) error {
+	t.Config.Filter = filter
+	t.Config.Mask = mask
+	return t.writeInitFrame()
+}
+
+// Shutdown stops the read goroutine and closes the serial connection,
+// waiting for the read goroutine to actually return until ctx is done
+func (t *USBCanAnalyzer) Shutdown(ctx context.Context) error {
 	if t.client == nil {
 		return nil
 	}
 
-	// Stop reading serial port
-	t.mutex.Lock()
-	t.running = false
-	t.mutex.Unlock()
-
-	close(t.readChan)
+	// Stop the read goroutine; it closes readChan itself once it returns
+	t.cancel()
 
-	return t.client.Close()
-}
+	done := make(chan struct{})
+	go func() {
+		t.wg.Wait()
+		close(done)
+	}()
 
-// Write a frame to serial connection
-func (t *USBCanAnalyzer) Write(frm *can.Frame) error {
-	frmFullLen := 4 + int(frm.DLC) + 1
-	data := make([]byte, frmFullLen)
+	// Close the port unconditionally, even if ctx expires before the read
+	// goroutine returns, so it's never leaked for the rest of the process
+	var waitErr error
+	select {
+	case <-done:
+	case <-ctx.Done():
+		waitErr = ctx.Err()
+	}
 
-	// 0xAA : adapter start of frame
-	data[0] = 0xAA
+	// Prefer surfacing waitErr (ctx expiring) over a close error, since
+	// callers check it with errors.Is(err, context.DeadlineExceeded) to
+	// detect the read goroutine not having stopped in time
+	closeErr := t.client.Close()
+	if waitErr != nil {
+		return waitErr
+	}
 
-	// DLC
-	data[1] = 0xC0 | frm.DLC
+	return closeErr
+}
 
-	// Write arbitration id
-	binary.LittleEndian.PutUint16(data[2:], uint16(frm.ArbitrationID))
+// ReadErr returns the last error encountered reading the serial connection,
+// if any
+func (t *USBCanAnalyzer) ReadErr() error {
+	err, _ := t.readErr.Load().(error)
+	return err
+}
 
-	// Append data
-	for i := 0; i < int(frm.DLC); i++ {
-		data[i+4] = frm.Data[i]
+// Write a frame to serial connection
+func (t *USBCanAnalyzer) Write(frm *can.Frame) error {
+	data, err := t.Framer.Encode(frm)
+	if err != nil {
+		return err
 	}
 
-	// Adapater end of frame
-	data[frmFullLen-1] = 0x55
-
-	_, err := t.client.Write(data)
+	_, err = t.client.Write(data)
 	return err
 }
 
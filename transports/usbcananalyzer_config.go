@@ -0,0 +1,154 @@
+package transports
+
+import (
+	"encoding/binary"
+	"errors"
+)
+
+// ErrUnsupportedCanalystBitrate is returned when a Bitrate has no matching
+// Canalyst init frame bitrate code
+var ErrUnsupportedCanalystBitrate = errors.New("transports: unsupported canalyst bitrate")
+
+// Bitrate is a CAN bus bit rate understood by USBCanAnalyzerConfig.Bitrate
+type Bitrate int
+
+// Supported CAN bus bit rates
+const (
+	Bitrate1M   Bitrate = 1000000
+	Bitrate800K Bitrate = 800000
+	Bitrate500K Bitrate = 500000
+	Bitrate250K Bitrate = 250000
+	Bitrate125K Bitrate = 125000
+	Bitrate100K Bitrate = 100000
+	Bitrate50K  Bitrate = 50000
+	Bitrate20K  Bitrate = 20000
+	Bitrate10K  Bitrate = 10000
+	Bitrate5K   Bitrate = 5000
+)
+
+// canalystBitrateCodes maps a Bitrate to its protocol code in the adapter's
+// init frame
+var canalystBitrateCodes = map[Bitrate]byte{
+	Bitrate1M:   0x01,
+	Bitrate800K: 0x02,
+	Bitrate500K: 0x03,
+	Bitrate250K: 0x04,
+	Bitrate125K: 0x05,
+	Bitrate100K: 0x06,
+	Bitrate50K:  0x07,
+	Bitrate20K:  0x08,
+	Bitrate10K:  0x09,
+	Bitrate5K:   0x0A,
+}
+
+// FrameType selects whether USBCanAnalyzerConfig.Filter/Mask apply to
+// standard (11-bit) or extended (29-bit) arbitration IDs
+type FrameType uint8
+
+// Supported frame types
+const (
+	FrameTypeStandard FrameType = 0x01
+	FrameTypeExtended FrameType = 0x02
+)
+
+// Mode selects the adapter's operating mode
+type Mode uint8
+
+// Supported modes
+const (
+	// ModeNormal participates normally on the bus, acking and sending frames
+	ModeNormal Mode = 0x00
+
+	// ModeLoopback routes written frames back to the read channel without
+	// touching the bus
+	ModeLoopback Mode = 0x01
+
+	// ModeSilent receives frames without acking or transmitting on the bus
+	ModeSilent Mode = 0x02
+
+	// ModeLoopbackSilent combines ModeLoopback and ModeSilent
+	ModeLoopbackSilent Mode = 0x03
+)
+
+// USBCanAnalyzerConfig configures the adapter's CAN bus parameters, sent as
+// its 20-byte init frame on Open
+type USBCanAnalyzerConfig struct {
+	// Bitrate is the CAN bus bit rate
+	Bitrate Bitrate
+
+	// FrameType selects whether Filter/Mask apply to standard or extended IDs
+	FrameType FrameType
+
+	// Filter is the acceptance filter code
+	Filter uint32
+
+	// Mask is the acceptance mask. A 0 bit means "don't care" for the
+	// corresponding Filter bit; a Mask of 0 accepts every frame
+	Mask uint32
+
+	// Mode selects the adapter's operating mode
+	Mode Mode
+
+	// AutoRetransmit enables automatic retransmission of frames that lost
+	// arbitration or hit a bus error
+	AutoRetransmit bool
+}
+
+// DefaultUSBCanAnalyzerConfig returns the config used when USBCanAnalyzer.Config
+// is left at its zero value: 50 Kbps, standard frames, no filtering, normal
+// mode, auto retransmission enabled. This reproduces the init frame this
+// package hardcoded before Config became configurable, so callers that never
+// set Config keep talking to their hardware the same way
+func DefaultUSBCanAnalyzerConfig() USBCanAnalyzerConfig {
+	return USBCanAnalyzerConfig{
+		Bitrate:        Bitrate50K,
+		FrameType:      FrameTypeStandard,
+		Mode:           ModeNormal,
+		AutoRetransmit: true,
+	}
+}
+
+// usbCanAnalyzerInitFrameLen is the size of the adapter's init command frame
+const usbCanAnalyzerInitFrameLen = 20
+
+// buildInitFrame lays out the 20-byte init command for cfg : SOF (0xAA 0x55)
+// + command id (0x12) + bitrate code + frame type + filter + mask + mode +
+// auto-retransmit flag + reserved bytes + checksum
+func buildInitFrame(cfg USBCanAnalyzerConfig) ([]byte, error) {
+	bitrateCode, ok := canalystBitrateCodes[cfg.Bitrate]
+	if !ok {
+		return nil, ErrUnsupportedCanalystBitrate
+	}
+
+	frm := make([]byte, usbCanAnalyzerInitFrameLen)
+
+	frm[0] = 0xAA
+	frm[1] = 0x55
+	frm[2] = 0x12
+	frm[3] = bitrateCode
+	frm[4] = byte(cfg.FrameType)
+
+	binary.LittleEndian.PutUint32(frm[5:9], cfg.Filter)
+	binary.LittleEndian.PutUint32(frm[9:13], cfg.Mask)
+
+	frm[13] = byte(cfg.Mode)
+
+	if cfg.AutoRetransmit {
+		frm[14] = 0x01
+	}
+
+	frm[19] = initFrameChecksum(frm)
+
+	return frm, nil
+}
+
+// initFrameChecksum computes the trailing checksum byte of an init frame as
+// the 8-bit sum of its bytes [2:19]
+func initFrameChecksum(frm []byte) byte {
+	var sum byte
+	for _, b := range frm[2:19] {
+		sum += b
+	}
+
+	return sum
+}
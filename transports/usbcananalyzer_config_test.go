@@ -0,0 +1,108 @@
+package transports
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestBuildInitFrame(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  USBCanAnalyzerConfig
+		want []byte
+	}{
+		{
+			// Must match the init frame this package hardcoded before
+			// Config became configurable: byte[3] = 0x07, checksum 0x1B
+			name: "default config",
+			cfg:  DefaultUSBCanAnalyzerConfig(),
+			want: []byte{
+				0xAA, 0x55, 0x12, 0x07, 0x01,
+				0x00, 0x00, 0x00, 0x00,
+				0x00, 0x00, 0x00, 0x00,
+				0x00, 0x01, 0x00, 0x00, 0x00, 0x00,
+				0x1B,
+			},
+		},
+		{
+			name: "500k bitrate with filter, mask and silent mode",
+			cfg: USBCanAnalyzerConfig{
+				Bitrate:   Bitrate500K,
+				FrameType: FrameTypeExtended,
+				Filter:    0x1ABCDE,
+				Mask:      0x1FFFFFFF,
+				Mode:      ModeSilent,
+			},
+			want: []byte{
+				0xAA, 0x55, 0x12, 0x03, 0x02,
+				0xDE, 0xBC, 0x1A, 0x00,
+				0xFF, 0xFF, 0xFF, 0x1F,
+				0x02, 0x00, 0x00, 0x00, 0x00, 0x00,
+				0xE9,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := buildInitFrame(tt.cfg)
+			if err != nil {
+				t.Fatalf("buildInitFrame returned error: %v", err)
+			}
+
+			if !bytes.Equal(got, tt.want) {
+				t.Fatalf("buildInitFrame = % X, want % X", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuildInitFrameUnsupportedBitrate(t *testing.T) {
+	_, err := buildInitFrame(USBCanAnalyzerConfig{Bitrate: 123456})
+	if err != ErrUnsupportedCanalystBitrate {
+		t.Fatalf("buildInitFrame err = %v, want ErrUnsupportedCanalystBitrate", err)
+	}
+}
+
+func TestCanalystBitrateCodes(t *testing.T) {
+	tests := []struct {
+		bitrate Bitrate
+		code    byte
+	}{
+		{Bitrate1M, 0x01},
+		{Bitrate800K, 0x02},
+		{Bitrate500K, 0x03},
+		{Bitrate250K, 0x04},
+		{Bitrate125K, 0x05},
+		{Bitrate100K, 0x06},
+		{Bitrate50K, 0x07},
+		{Bitrate20K, 0x08},
+		{Bitrate10K, 0x09},
+		{Bitrate5K, 0x0A},
+	}
+
+	for _, tt := range tests {
+		got, ok := canalystBitrateCodes[tt.bitrate]
+		if !ok {
+			t.Fatalf("no code for bitrate %v", tt.bitrate)
+		}
+
+		if got != tt.code {
+			t.Fatalf("code for bitrate %v = %#x, want %#x", tt.bitrate, got, tt.code)
+		}
+	}
+}
+
+func TestInitFrameChecksum(t *testing.T) {
+	frm := make([]byte, usbCanAnalyzerInitFrameLen)
+	frm[0] = 0xAA
+	frm[1] = 0x55
+	frm[2] = 0x12
+	frm[3] = 0x07
+	frm[4] = 0x01
+	frm[14] = 0x01
+
+	if got := initFrameChecksum(frm); got != 0x1B {
+		t.Fatalf("initFrameChecksum = %#x, want 0x1B", got)
+	}
+}
@@ -0,0 +1,104 @@
+package transports
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jaster-prj/go-can"
+)
+
+// TestUSBCanAnalyzerShutdownUnopened checks that Shutdown is a safe no-op
+// when the adapter was never opened
+func TestUSBCanAnalyzerShutdownUnopened(t *testing.T) {
+	assertShutdownUnopened(t, &USBCanAnalyzer{})
+}
+
+// TestUSBCanAnalyzerShutdownWithoutDrainingReadChan opens a USBCanAnalyzer
+// against a port that keeps producing valid frames and shuts it down without
+// ever reading ReadChan(), the normal "stop reading, then tear down"
+// sequence. Shutdown must still close the port instead of leaking the read
+// goroutine blocked on the readChan send
+func TestUSBCanAnalyzerShutdownWithoutDrainingReadChan(t *testing.T) {
+	framer := &CanalystBinaryFramer{}
+
+	captured, err := framer.Encode(&can.Frame{ArbitrationID: 0x123, DLC: 3, Data: [8]byte{0x01, 0x02, 0x03}})
+	if err != nil {
+		t.Fatalf("Encode returned error: %v", err)
+	}
+
+	port := &fakeSerialPort{toRead: captured, repeat: true}
+	tr := &USBCanAnalyzer{Opener: func(cfg SerialConfig) (SerialPort, error) { return port, nil }}
+
+	assertShutdownWithoutDrainingReadChan(t, tr, port)
+}
+
+// TestUSBCanAnalyzerShutdownClosesPortDespiteSlowReadGoroutine checks that
+// Shutdown closes the port even when its ctx expires before the read
+// goroutine notices cancellation, instead of leaking the port for the rest
+// of the process
+func TestUSBCanAnalyzerShutdownClosesPortDespiteSlowReadGoroutine(t *testing.T) {
+	port := &fakeSerialPort{repeat: true, readDelay: 200 * time.Millisecond, readStarted: make(chan struct{})}
+	tr := &USBCanAnalyzer{Opener: func(cfg SerialConfig) (SerialPort, error) { return port, nil }}
+
+	assertShutdownClosesPortDespiteSlowReadGoroutine(t, tr, port)
+}
+
+// TestUSBCanAnalyzerDataBufCap exercises the dataBuf append+cap path under
+// -race through the real Open/run()/publishFrames code path: a continuous
+// stream of bytes that never forms a valid frame must still leave dataBuf
+// capped at usbCanAnalyzerMaxDataBufLen instead of growing unboundedly
+func TestUSBCanAnalyzerDataBufCap(t *testing.T) {
+	// A run of 0xAA bytes resyncs as a SOF with a DLC nibble (0xA) that's
+	// always > 8, so each Decode call drops a single byte (errBadCanalystDLC)
+	// while every Read appends a full chunk, growing dataBuf net positive
+	// until the cap kicks in
+	port := &fakeSerialPort{toRead: bytes.Repeat([]byte{0xAA}, 64), repeat: true}
+	tr := &USBCanAnalyzer{Opener: func(cfg SerialConfig) (SerialPort, error) { return port, nil }}
+
+	if err := tr.Open(context.Background()); err != nil {
+		t.Fatalf("Open returned error: %v", err)
+	}
+	defer tr.Shutdown(context.Background())
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		tr.mutex.Lock()
+		n := len(tr.dataBuf)
+		tr.mutex.Unlock()
+
+		if n > usbCanAnalyzerMaxDataBufLen {
+			t.Fatalf("dataBuf len = %d, want <= %d", n, usbCanAnalyzerMaxDataBufLen)
+		}
+
+		if n == usbCanAnalyzerMaxDataBufLen {
+			break
+		}
+
+		if time.Now().After(deadline) {
+			t.Fatalf("dataBuf never reached usbCanAnalyzerMaxDataBufLen, got %d", n)
+		}
+
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// TestUSBCanAnalyzerOpenShutdownLoop opens and shuts down many
+// USBCanAnalyzers concurrently against ports that keep producing valid
+// frames, exercising the real run()/publishFrames lifecycle under -race
+func TestUSBCanAnalyzerOpenShutdownLoop(t *testing.T) {
+	framer := &CanalystBinaryFramer{}
+
+	captured, err := framer.Encode(&can.Frame{ArbitrationID: 0x123, DLC: 3, Data: [8]byte{0x01, 0x02, 0x03}})
+	if err != nil {
+		t.Fatalf("Encode returned error: %v", err)
+	}
+
+	assertOpenShutdownLoop(t, 50, func() (lifecycleTransport, *fakeSerialPort) {
+		port := &fakeSerialPort{toRead: captured, repeat: true}
+		tr := &USBCanAnalyzer{Opener: func(cfg SerialConfig) (SerialPort, error) { return port, nil }}
+
+		return tr, port
+	})
+}
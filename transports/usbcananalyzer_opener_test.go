@@ -0,0 +1,66 @@
+package transports
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/jaster-prj/go-can"
+)
+
+// TestUSBCanAnalyzerOpenDecodesFrame opens a USBCanAnalyzer against a fake
+// port preloaded with a captured Canalyst binary frame and checks it comes
+// out the other end of ReadChan decoded
+func TestUSBCanAnalyzerOpenDecodesFrame(t *testing.T) {
+	framer := &CanalystBinaryFramer{}
+
+	want := &can.Frame{ArbitrationID: 0x123, DLC: 3, Data: [8]byte{0x01, 0x02, 0x03}}
+
+	captured, err := framer.Encode(want)
+	if err != nil {
+		t.Fatalf("Encode returned error: %v", err)
+	}
+
+	port := &fakeSerialPort{toRead: captured}
+	tr := &USBCanAnalyzer{Opener: func(cfg SerialConfig) (SerialPort, error) { return port, nil }}
+
+	assertOpenDecodesFrame(t, tr, want)
+}
+
+// TestUSBCanAnalyzerSetFilterRewritesInitFrame checks that SetFilter resends
+// a rebuilt init frame over the already open port
+func TestUSBCanAnalyzerSetFilterRewritesInitFrame(t *testing.T) {
+	port := &fakeSerialPort{}
+
+	tr := &USBCanAnalyzer{Opener: func(cfg SerialConfig) (SerialPort, error) { return port, nil }}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	if err := tr.Open(ctx); err != nil {
+		t.Fatalf("Open returned error: %v", err)
+	}
+	defer tr.Shutdown(context.Background())
+
+	if err := tr.SetFilter(0x1ABCDE, 0x1FFFFF); err != nil {
+		t.Fatalf("SetFilter returned error: %v", err)
+	}
+
+	want, err := buildInitFrame(tr.Config)
+	if err != nil {
+		t.Fatalf("buildInitFrame returned error: %v", err)
+	}
+
+	if got := port.writtenBytes(); !bytes.HasSuffix(got, want) {
+		t.Fatalf("SetFilter did not write the rebuilt init frame as the last bytes written; got % X, want suffix % X", got, want)
+	}
+}
+
+// TestUSBCanAnalyzerShutdownClosesPort checks that Shutdown closes the
+// underlying port once opened
+func TestUSBCanAnalyzerShutdownClosesPort(t *testing.T) {
+	port := &fakeSerialPort{}
+	tr := &USBCanAnalyzer{Opener: func(cfg SerialConfig) (SerialPort, error) { return port, nil }}
+
+	assertShutdownClosesPort(t, tr, port)
+}